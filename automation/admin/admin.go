@@ -0,0 +1,118 @@
+// Package admin exposes a small, localhost-only HTTP API for operating the
+// server without going through its raw console: triggering backups,
+// listing players, broadcasting messages, and shutting down.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"syscall"
+
+	"github.com/kCanoe/mc-server-wrapper/automation/backup"
+	"github.com/kCanoe/mc-server-wrapper/automation/logtail"
+	"github.com/kCanoe/mc-server-wrapper/automation/rcon"
+)
+
+// Server is the admin HTTP API. It's bound to localhost only and every
+// request must carry the configured bearer token.
+type Server struct {
+	token     string
+	rcon      *rcon.Client
+	backupSvc *backup.Service
+	coord     backup.SaveCoordinator
+	logs      *logtail.Ring
+	shutdown  chan<- os.Signal
+}
+
+// New builds an admin Server. rconClient may be nil if RCON isn't
+// configured, in which case /players and /rcon return an error.
+func New(token string, rconClient *rcon.Client, backupSvc *backup.Service, coord backup.SaveCoordinator, logs *logtail.Ring, shutdown chan<- os.Signal) *Server {
+	return &Server{token: token, rcon: rconClient, backupSvc: backupSvc, coord: coord, logs: logs, shutdown: shutdown}
+}
+
+// Handler returns the routed, token-checked http.Handler to bind to
+// 127.0.0.1.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rcon", s.handleRcon)
+	mux.HandleFunc("/players", s.handlePlayers)
+	mux.HandleFunc("/backup", s.handleBackup)
+	mux.HandleFunc("/stop", s.handleStop)
+	mux.HandleFunc("/logs", s.handleLogs)
+	return s.authenticate(mux)
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+s.token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type rconRequest struct {
+	Command string `json:"command"`
+}
+
+type rconResponse struct {
+	Output string `json:"output"`
+}
+
+func (s *Server) handleRcon(w http.ResponseWriter, r *http.Request) {
+	if s.rcon == nil {
+		http.Error(w, "rcon not configured", http.StatusServiceUnavailable)
+		return
+	}
+	var req rconRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("bad request: %v", err), http.StatusBadRequest)
+		return
+	}
+	out, err := s.rcon.Execute(req.Command)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	writeJSON(w, rconResponse{Output: out})
+}
+
+func (s *Server) handlePlayers(w http.ResponseWriter, r *http.Request) {
+	if s.rcon == nil {
+		http.Error(w, "rcon not configured", http.StatusServiceUnavailable)
+		return
+	}
+	out, err := s.rcon.Execute("list")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	writeJSON(w, rconResponse{Output: out})
+}
+
+func (s *Server) handleBackup(w http.ResponseWriter, r *http.Request) {
+	if err := s.backupSvc.Run(context.Background(), s.coord); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
+	s.shutdown <- syscall.SIGTERM
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleLogs returns the buffered tail of recent, parsed server log lines.
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.logs.Snapshot())
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,366 @@
+// Package backup implements scheduled, incremental world backups with
+// upload to GCS and retention-based pruning of old snapshots.
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// Config holds the fixed parameters of the backup subsystem, sourced from
+// the same env file as the rest of automation.
+type Config struct {
+	Bucket       string
+	WorldDir     string // absolute path to the world save directory
+	ServerDir    string // SERVER_JAR_PATH, used as the tar working directory
+	ManifestPath string // local path to the incremental manifest
+	Interval     time.Duration
+	KeepFull     int
+	KeepIncr     int
+}
+
+// Service runs scheduled backups of the Minecraft world directory against a
+// running server process, coordinating save-off/save-all via a
+// SaveCoordinator so that each snapshot is crash-consistent.
+type Service struct {
+	cfg    Config
+	client *storage.Client
+
+	// runMu serializes Run so the scheduled-backup goroutine, the admin
+	// API's on-demand /backup, and the shutdown backup can't overlap: two
+	// interleaved runs would race lastFull/lastSnapshot, race the manifest
+	// file, and could resume saves on the server while another run's
+	// archive is still being built.
+	runMu sync.Mutex
+
+	lastFull     string // object name of the most recent full snapshot
+	lastSnapshot string // object name of the most recent snapshot of either kind, i.e. the incremental parent chain's tip
+}
+
+// NewService builds a backup Service backed by a GCS client. Callers must
+// call Close when the service is no longer needed.
+func NewService(ctx context.Context, cfg Config) (*Service, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("storage.NewClient: %w", err)
+	}
+	return &Service{cfg: cfg, client: client}, nil
+}
+
+// Close releases the underlying GCS client.
+func (s *Service) Close() error {
+	return s.client.Close()
+}
+
+// Run performs a single backup cycle: pause world saves via coord, snapshot
+// changed region files (full if this Service hasn't yet taken or restored a
+// full snapshot, so the first run of every process is full; incremental
+// otherwise), upload the result and its manifest, resume world saves, and
+// prune old snapshots per the retention policy.
+//
+// coord is either a StdinCoordinator or a RconCoordinator depending on
+// whether the admin RCON connection is up; callers pick which to pass in.
+func (s *Service) Run(ctx context.Context, coord SaveCoordinator) error {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	manifest, err := loadManifest(s.cfg.ManifestPath)
+	if err != nil {
+		return fmt.Errorf("loadManifest: %w", err)
+	}
+
+	if err := coord.Quiesce(); err != nil {
+		return fmt.Errorf("quiesce: %w", err)
+	}
+
+	changed, err := changedRegionFiles(s.cfg.WorldDir, manifest)
+	if err != nil {
+		return fmt.Errorf("changedRegionFiles: %w", err)
+	}
+
+	kind := "incremental"
+	if s.lastFull == "" {
+		kind = "full"
+	}
+
+	name := snapshotName(kind)
+	archivePath := filepath.Join(s.cfg.ServerDir, name)
+	var files []string
+	if kind == "full" {
+		files, err = allRegionFiles(s.cfg.WorldDir)
+	} else {
+		files = changed
+	}
+	if err != nil {
+		return fmt.Errorf("listing region files: %w", err)
+	}
+
+	if err := compressFiles(s.cfg.ServerDir, archivePath, s.cfg.WorldDir, files); err != nil {
+		return fmt.Errorf("compressFiles: %w", err)
+	}
+	defer os.Remove(archivePath)
+
+	if err := s.uploadWithRetry(ctx, name, archivePath); err != nil {
+		return fmt.Errorf("upload snapshot: %w", err)
+	}
+
+	// the archive is safely uploaded, so the world can resume writing region
+	// files now; everything after this point (manifest bookkeeping, pruning)
+	// doesn't touch the region files themselves
+	resumeErr := coord.Resume()
+
+	snap := &snapshotManifest{
+		Name:      name,
+		Kind:      kind,
+		CreatedAt: time.Now().Unix(),
+		Files:     files,
+	}
+	if kind == "incremental" {
+		snap.Parent = s.lastSnapshot
+	}
+	if err := s.uploadSnapshotManifest(ctx, snap); err != nil {
+		return fmt.Errorf("upload snapshot manifest: %w", err)
+	}
+
+	for _, f := range files {
+		abs := filepath.Join(s.cfg.WorldDir, f)
+		info, err := os.Stat(abs)
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", f, err)
+		}
+		if err := manifest.update(f, abs, info); err != nil {
+			return fmt.Errorf("update manifest for %s: %w", f, err)
+		}
+	}
+
+	// the archive is safely uploaded and the manifest has recorded the state
+	// of the region files it holds, so the world can resume writing region
+	// files now; everything after this point (saving the manifest, pruning)
+	// doesn't touch the region files themselves
+	resumeErr := coord.Resume()
+
+	if err := manifest.save(); err != nil {
+		return fmt.Errorf("save manifest: %w", err)
+	}
+
+	if kind == "full" {
+		s.lastFull = name
+	}
+	s.lastSnapshot = name
+
+	if err := s.prune(ctx); err != nil {
+		return fmt.Errorf("prune: %w", err)
+	}
+
+	return resumeErr
+}
+
+func snapshotName(kind string) string {
+	timeString := strings.ReplaceAll(time.Now().Format("2006-01-02T15:04:05"), ":", "-")
+	if kind == "full" {
+		return "mc-world-backup-" + timeString + ".tar.xz"
+	}
+	return "mc-world-incr-" + timeString + ".tar.xz"
+}
+
+// compressFiles tars+xz-compresses the given region-relative file paths
+// (rooted at worldDir) into destPath.
+func compressFiles(workDir, destPath, worldDir string, files []string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("no files to archive")
+	}
+	rel, err := filepath.Rel(workDir, worldDir)
+	if err != nil {
+		return err
+	}
+	args := []string{"-cJf", destPath}
+	for _, f := range files {
+		args = append(args, filepath.Join(rel, f))
+	}
+	cmd := exec.Command("tar", args...)
+	cmd.Dir = workDir
+	return cmd.Run()
+}
+
+func allRegionFiles(worldDir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(worldDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := relPath(worldDir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	return files, err
+}
+
+func changedRegionFiles(worldDir string, m *Manifest) ([]string, error) {
+	var files []string
+	err := filepath.Walk(worldDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := relPath(worldDir, p)
+		if err != nil {
+			return err
+		}
+		if m.changed(rel, info) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func (s *Service) uploadSnapshotManifest(ctx context.Context, snap *snapshotManifest) error {
+	data, err := marshalSnapshotManifest(snap)
+	if err != nil {
+		return err
+	}
+	wc := s.client.Bucket(s.cfg.Bucket).Object(snap.objectName()).NewWriter(ctx)
+	if _, err := wc.Write(data); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return wc.Close()
+}
+
+// prune enforces the retention policy: keep the newest KeepFull full
+// snapshots and KeepIncr incrementals, deleting everything older along with
+// its companion manifest blob. A full (or incremental) that a retained
+// incremental's Parent chain still depends on is never deleted, even if it
+// falls outside its own retention count, since doing so would orphan the
+// incrementals built on top of it.
+func (s *Service) prune(ctx context.Context) error {
+	bucket := s.client.Bucket(s.cfg.Bucket)
+
+	var fulls, incrs []string
+	it := bucket.Objects(ctx, &storage.Query{Prefix: "mc-world-"})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("listing objects: %w", err)
+		}
+		if strings.HasSuffix(attrs.Name, ".manifest.json") {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(attrs.Name, "mc-world-backup-"):
+			fulls = append(fulls, attrs.Name)
+		case strings.HasPrefix(attrs.Name, "mc-world-incr-"):
+			incrs = append(incrs, attrs.Name)
+		}
+	}
+
+	protected, err := s.ancestorChain(ctx, bucket, namesToKeep(incrs, s.cfg.KeepIncr))
+	if err != nil {
+		return fmt.Errorf("ancestorChain: %w", err)
+	}
+
+	if err := s.deleteSnapshots(ctx, bucket, subtract(namesToPrune(fulls, s.cfg.KeepFull), protected)); err != nil {
+		return err
+	}
+	return s.deleteSnapshots(ctx, bucket, subtract(namesToPrune(incrs, s.cfg.KeepIncr), protected))
+}
+
+// ancestorChain downloads the snapshot manifest of each name in names and
+// follows its Parent chain back to the root full, returning the set of every
+// snapshot name visited along the way. Those are the snapshots prune must
+// never delete, since a retained incremental can only be restored by
+// replaying its whole ancestor chain.
+func (s *Service) ancestorChain(ctx context.Context, bucket *storage.BucketHandle, names []string) (map[string]bool, error) {
+	visited := map[string]bool{}
+	for _, name := range names {
+		for cur := name; cur != "" && !visited[cur]; {
+			visited[cur] = true
+			snap, err := s.downloadSnapshotManifest(ctx, bucket, cur)
+			if err != nil {
+				return nil, fmt.Errorf("downloadSnapshotManifest %s: %w", cur, err)
+			}
+			cur = snap.Parent
+		}
+	}
+	return visited, nil
+}
+
+func (s *Service) downloadSnapshotManifest(ctx context.Context, bucket *storage.BucketHandle, name string) (*snapshotManifest, error) {
+	rc, err := bucket.Object(snapshotManifestObject(name)).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("NewReader: %w", err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("ReadAll: %w", err)
+	}
+	var snap snapshotManifest
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("Unmarshal: %w", err)
+	}
+	return &snap, nil
+}
+
+func (s *Service) deleteSnapshots(ctx context.Context, bucket *storage.BucketHandle, names []string) error {
+	for _, name := range names {
+		if err := bucket.Object(name).Delete(ctx); err != nil {
+			return fmt.Errorf("delete %s: %w", name, err)
+		}
+		if err := bucket.Object(snapshotManifestObject(name)).Delete(ctx); err != nil && err != storage.ErrObjectNotExist {
+			return fmt.Errorf("delete %s: %w", snapshotManifestObject(name), err)
+		}
+	}
+	return nil
+}
+
+// namesToPrune returns the oldest of names that fall outside the newest keep,
+// relying on their timestamped names sorting chronologically. It returns nil
+// if there aren't more than keep names.
+func namesToPrune(names []string, keep int) []string {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+	if len(sorted) <= keep {
+		return nil
+	}
+	return sorted[:len(sorted)-keep]
+}
+
+// namesToKeep returns the newest keep of names, the complement of
+// namesToPrune.
+func namesToKeep(names []string, keep int) []string {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+	if len(sorted) <= keep {
+		return sorted
+	}
+	return sorted[len(sorted)-keep:]
+}
+
+// subtract returns the names not present in exclude.
+func subtract(names []string, exclude map[string]bool) []string {
+	var out []string
+	for _, name := range names {
+		if !exclude[name] {
+			out = append(out, name)
+		}
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package backup
+
+import "testing"
+
+func TestNamesToPruneKeepsNewest(t *testing.T) {
+	names := []string{
+		"mc-world-backup-2024-01-03T00-00-00.tar.xz",
+		"mc-world-backup-2024-01-01T00-00-00.tar.xz",
+		"mc-world-backup-2024-01-02T00-00-00.tar.xz",
+	}
+	got := namesToPrune(names, 2)
+	want := []string{"mc-world-backup-2024-01-01T00-00-00.tar.xz"}
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Errorf("namesToPrune(names, 2) = %v, want %v", got, want)
+	}
+}
+
+func TestNamesToPruneNoneWhenUnderLimit(t *testing.T) {
+	names := []string{"a", "b"}
+	if got := namesToPrune(names, 5); got != nil {
+		t.Errorf("namesToPrune(names, 5) = %v, want nil", got)
+	}
+}
+
+func TestNamesToPruneDoesNotMutateInput(t *testing.T) {
+	names := []string{"c", "a", "b"}
+	namesToPrune(names, 1)
+	if names[0] != "c" || names[1] != "a" || names[2] != "b" {
+		t.Errorf("namesToPrune mutated its input slice: %v", names)
+	}
+}
+
+func TestNamesToKeepIsComplementOfNamesToPrune(t *testing.T) {
+	names := []string{"c", "a", "b", "d"}
+	pruned := namesToPrune(names, 1)
+	kept := namesToKeep(names, 1)
+	if len(pruned)+len(kept) != len(names) {
+		t.Fatalf("namesToPrune+namesToKeep = %d+%d, want %d", len(pruned), len(kept), len(names))
+	}
+	if kept[len(kept)-1] != "d" {
+		t.Errorf("namesToKeep(names, 1) = %v, want newest last (%q)", kept, "d")
+	}
+}
+
+func TestNamesToKeepAllWhenUnderLimit(t *testing.T) {
+	names := []string{"a", "b"}
+	got := namesToKeep(names, 5)
+	if len(got) != 2 {
+		t.Errorf("namesToKeep(names, 5) = %v, want all of %v", got, names)
+	}
+}
+
+func TestSubtractRemovesExcluded(t *testing.T) {
+	names := []string{"a", "b", "c"}
+	exclude := map[string]bool{"b": true}
+	got := subtract(names, exclude)
+	want := []string{"a", "c"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("subtract(names, exclude) = %v, want %v", got, want)
+	}
+}
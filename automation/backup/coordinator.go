@@ -0,0 +1,84 @@
+package backup
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kCanoe/mc-server-wrapper/automation/logtail"
+)
+
+// SaveCoordinator pauses and resumes world autosaving around a snapshot so
+// that the region files on disk are stable while they're archived.
+type SaveCoordinator interface {
+	Quiesce() error
+	Resume() error
+}
+
+// StdinCoordinator issues save-off/save-all/save-on over the server
+// process's raw stdin pipe, the only option when no RCON connection is
+// available, and waits for the "Saved the game" line via the server's
+// logtail.Tailer to know the flush has actually completed.
+type StdinCoordinator struct {
+	stdin  io.Writer
+	tailer *logtail.Tailer
+}
+
+// NewStdinCoordinator builds a StdinCoordinator over the server's console
+// stdin and the Tailer draining its log output.
+func NewStdinCoordinator(stdin io.Writer, tailer *logtail.Tailer) *StdinCoordinator {
+	return &StdinCoordinator{stdin: stdin, tailer: tailer}
+}
+
+func (c *StdinCoordinator) Quiesce() error {
+	if _, err := io.WriteString(c.stdin, "save-off\n"); err != nil {
+		return fmt.Errorf("save-off: %w", err)
+	}
+	if _, err := io.WriteString(c.stdin, "save-all flush\n"); err != nil {
+		return fmt.Errorf("save-all flush: %w", err)
+	}
+	return c.tailer.WaitForLine("Saved the game", 30*time.Second)
+}
+
+func (c *StdinCoordinator) Resume() error {
+	if _, err := io.WriteString(c.stdin, "save-on\n"); err != nil {
+		return fmt.Errorf("save-on: %w", err)
+	}
+	return nil
+}
+
+// RconExecutor is the subset of *rcon.Client that RconCoordinator needs,
+// kept minimal so this package doesn't have to import the rcon client.
+type RconExecutor interface {
+	Execute(command string) (string, error)
+}
+
+// RconCoordinator issues save-off/save-all/save-on over an RCON connection.
+// Unlike StdinCoordinator it doesn't need to separately wait for a log
+// line: "save-all flush" only responds once the flush has completed.
+type RconCoordinator struct {
+	rcon RconExecutor
+}
+
+// NewRconCoordinator builds a RconCoordinator over an already-authenticated
+// RCON connection.
+func NewRconCoordinator(rcon RconExecutor) *RconCoordinator {
+	return &RconCoordinator{rcon: rcon}
+}
+
+func (c *RconCoordinator) Quiesce() error {
+	if _, err := c.rcon.Execute("save-off"); err != nil {
+		return fmt.Errorf("save-off: %w", err)
+	}
+	if _, err := c.rcon.Execute("save-all flush"); err != nil {
+		return fmt.Errorf("save-all flush: %w", err)
+	}
+	return nil
+}
+
+func (c *RconCoordinator) Resume() error {
+	if _, err := c.rcon.Execute("save-on"); err != nil {
+		return fmt.Errorf("save-on: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// regionState records the mtime and content hash of a single region file
+// as of the last successful snapshot that included it.
+type regionState struct {
+	Path    string `json:"path"`
+	ModTime int64  `json:"mod_time"`
+	SHA256  string `json:"sha256"`
+}
+
+// Manifest is the local record of what has already been backed up, used to
+// decide which region files belong in the next incremental snapshot. It is
+// only saved locally; the separate snapshotManifest is what's written
+// alongside each uploaded blob so that a restore can see exactly what a
+// snapshot contains and which snapshot it was taken relative to.
+type Manifest struct {
+	path   string
+	Region map[string]regionState `json:"region"`
+}
+
+// loadManifest reads the manifest at path, returning an empty Manifest if no
+// manifest has been written yet (e.g. first run).
+func loadManifest(path string) (*Manifest, error) {
+	m := &Manifest{path: path, Region: map[string]regionState{}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// save writes the manifest back to its local path.
+func (m *Manifest) save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.path, data, 0644)
+}
+
+// changed reports whether the file identified by relPath (its path relative
+// to the world directory) is unknown to the manifest or has a different
+// mtime from the one last recorded for it. The recorded hash is not
+// compared.
+func (m *Manifest) changed(relPath string, info os.FileInfo) bool {
+	prev, ok := m.Region[relPath]
+	return !ok || prev.ModTime != info.ModTime().Unix()
+}
+
+// update records the current state of the region file at absPath under key
+// relPath, hashing its contents.
+func (m *Manifest) update(relPath, absPath string, info os.FileInfo) error {
+	hash, err := hashFile(absPath)
+	if err != nil {
+		return err
+	}
+	m.Region[relPath] = regionState{
+		Path:    relPath,
+		ModTime: info.ModTime().Unix(),
+		SHA256:  hash,
+	}
+	return nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// snapshotManifest is the small JSON document uploaded alongside each backup
+// blob, recording what it contains and what it was built on top of.
+type snapshotManifest struct {
+	Name      string   `json:"name"`
+	Kind      string   `json:"kind"` // "full" or "incremental"
+	Parent    string   `json:"parent,omitempty"`
+	CreatedAt int64    `json:"created_at"`
+	Files     []string `json:"files"`
+}
+
+func snapshotManifestObject(name string) string {
+	return name + ".manifest.json"
+}
+
+func (s *snapshotManifest) objectName() string {
+	return snapshotManifestObject(s.Name)
+}
+
+func marshalSnapshotManifest(s *snapshotManifest) ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+func relPath(worldDir, absPath string) (string, error) {
+	return filepath.Rel(worldDir, absPath)
+}
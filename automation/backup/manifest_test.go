@@ -0,0 +1,75 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManifestChangedForUnknownFile(t *testing.T) {
+	m := &Manifest{Region: map[string]regionState{}}
+	info := writeTempFile(t, "region/r.0.0.mca", "hello")
+	if !m.changed("region/r.0.0.mca", info) {
+		t.Error("changed() = false for a file never seen before, want true")
+	}
+}
+
+func TestManifestUpdateThenUnchanged(t *testing.T) {
+	m := &Manifest{Region: map[string]regionState{}}
+	abs := filepath.Join(t.TempDir(), "r.0.0.mca")
+	if err := os.WriteFile(abs, []byte("hello"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+
+	if err := m.update("region/r.0.0.mca", abs, info); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if m.changed("region/r.0.0.mca", info) {
+		t.Error("changed() = true right after update(), want false")
+	}
+}
+
+func TestManifestChangedAfterModTimeBump(t *testing.T) {
+	m := &Manifest{Region: map[string]regionState{}}
+	abs := filepath.Join(t.TempDir(), "r.0.0.mca")
+	if err := os.WriteFile(abs, []byte("hello"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if err := m.update("region/r.0.0.mca", abs, info); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	later := info.ModTime().Add(time.Minute)
+	if err := os.Chtimes(abs, later, later); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+	bumped, err := os.Stat(abs)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if !m.changed("region/r.0.0.mca", bumped) {
+		t.Error("changed() = false after mod time bump, want true")
+	}
+}
+
+func writeTempFile(t *testing.T, name, contents string) os.FileInfo {
+	t.Helper()
+	abs := filepath.Join(t.TempDir(), filepath.Base(name))
+	if err := os.WriteFile(abs, []byte(contents), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	return info
+}
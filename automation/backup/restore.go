@@ -0,0 +1,133 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// RestoreLatest downloads the newest full snapshot in the bucket and
+// extracts it into destDir, for bringing up a fresh VM from the latest
+// backup before the server is started. It does not attempt to replay any
+// incrementals on top; operators who need that can fetch and apply them
+// via the snapshot manifests directly.
+func (s *Service) RestoreLatest(ctx context.Context, destDir string) error {
+	name, err := s.latestFull(ctx)
+	if err != nil {
+		return fmt.Errorf("latestFull: %w", err)
+	}
+	if name == "" {
+		return fmt.Errorf("no full backups found in bucket %s", s.cfg.Bucket)
+	}
+
+	archivePath := filepath.Join(destDir, name)
+	fmt.Printf("restoring %s to %s\n", name, destDir)
+	if err := s.downloadFile(ctx, name, archivePath); err != nil {
+		return fmt.Errorf("downloadFile: %w", err)
+	}
+	defer os.Remove(archivePath)
+
+	if err := extractArchive(destDir, archivePath); err != nil {
+		return err
+	}
+
+	// Seed the local manifest from the files we just extracted. Without
+	// this, the manifest stays empty and the next Run() sees every region
+	// file as "changed", producing a full-sized blob mislabeled as
+	// incremental (and thrown off the KeepFull retention count).
+	if err := s.rebuildManifest(); err != nil {
+		return fmt.Errorf("rebuildManifest: %w", err)
+	}
+
+	// so the next Run() takes an incremental against this snapshot instead
+	// of redundantly re-uploading the world it was just restored from
+	s.lastFull = name
+	s.lastSnapshot = name
+	return nil
+}
+
+// rebuildManifest records the current on-disk state of every region file as
+// the manifest baseline, used after a restore so the next backup diffs
+// against what's actually on disk instead of an empty manifest.
+func (s *Service) rebuildManifest() error {
+	manifest, err := loadManifest(s.cfg.ManifestPath)
+	if err != nil {
+		return fmt.Errorf("loadManifest: %w", err)
+	}
+
+	files, err := allRegionFiles(s.cfg.WorldDir)
+	if err != nil {
+		return fmt.Errorf("allRegionFiles: %w", err)
+	}
+	for _, f := range files {
+		abs := filepath.Join(s.cfg.WorldDir, f)
+		info, err := os.Stat(abs)
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", f, err)
+		}
+		if err := manifest.update(f, abs, info); err != nil {
+			return fmt.Errorf("update manifest for %s: %w", f, err)
+		}
+	}
+	return manifest.save()
+}
+
+// latestFull returns the name of the newest mc-world-backup-*.tar.xz object
+// in the bucket, or "" if none exist.
+func (s *Service) latestFull(ctx context.Context) (string, error) {
+	var fulls []string
+	it := s.client.Bucket(s.cfg.Bucket).Objects(ctx, &storage.Query{Prefix: "mc-world-backup-"})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("listing objects: %w", err)
+		}
+		if strings.HasSuffix(attrs.Name, ".manifest.json") {
+			continue
+		}
+		fulls = append(fulls, attrs.Name)
+	}
+	if len(fulls) == 0 {
+		return "", nil
+	}
+	sort.Strings(fulls) // timestamped names sort chronologically
+	return fulls[len(fulls)-1], nil
+}
+
+// downloadFile streams object name from the bucket to destPath.
+func (s *Service) downloadFile(ctx context.Context, name, destPath string) error {
+	rc, err := s.client.Bucket(s.cfg.Bucket).Object(name).NewReader(ctx)
+	if err != nil {
+		return fmt.Errorf("Object.NewReader: %w", err)
+	}
+	defer rc.Close()
+
+	f, err := os.Create(destPath)
+	if err != nil {
+		return fmt.Errorf("os.Create: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, rc); err != nil {
+		return fmt.Errorf("io.Copy: %w", err)
+	}
+	return nil
+}
+
+// extractArchive unpacks the tar.xz archive at archivePath into destDir.
+func extractArchive(destDir, archivePath string) error {
+	cmd := exec.Command("tar", "-xJf", archivePath)
+	cmd.Dir = destDir
+	return cmd.Run()
+}
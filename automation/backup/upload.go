@@ -0,0 +1,103 @@
+package backup
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+)
+
+// resumableChunkSize sizes each chunk of the resumable upload session. GCS
+// buffers and retries at this granularity, so a dropped connection partway
+// through a multi-GB tarball only costs one chunk, not the whole upload.
+const resumableChunkSize = 16 * 1024 * 1024
+
+// uploadTimeout bounds how long the whole resumable upload is allowed to
+// take, scaled by the number of chunks it'll take instead of the old flat
+// 60s budget for the whole upload, which was never going to survive a
+// multi-GB world tarball on a residential uplink.
+func uploadTimeout(size int64) time.Duration {
+	chunks := size/resumableChunkSize + 1
+	return time.Duration(chunks) * 30 * time.Second
+}
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// uploadWithRetry uploads the file at path to object name, retrying with
+// exponential backoff on transient failures. Each attempt re-opens the
+// object handle and writer from scratch, since a storage.Writer can't be
+// reused once it's failed.
+func (s *Service) uploadWithRetry(ctx context.Context, name, path string) error {
+	backoff := 2 * time.Second
+	var lastErr error
+	for attempt := 0; attempt < 5; attempt++ {
+		if attempt > 0 {
+			fmt.Printf("retrying upload of %s after error: %v\n", name, lastErr)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		if err := s.uploadOnce(ctx, name, path); err != nil {
+			lastErr = err
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("upload of %s failed after retries: %w", name, lastErr)
+}
+
+// uploadOnce hashes path, then streams it to the named object in resumable
+// chunks. The hashes have to be known before the first byte is written:
+// the storage.Writer captures CRC32C/Metadata into the resumable session
+// when it opens on the first Write, so there's no setting them afterward.
+func (s *Service) uploadOnce(ctx context.Context, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("os.Open: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("Stat: %w", err)
+	}
+
+	md5Sum, crc32cSum, err := hashFileContents(f)
+	if err != nil {
+		return fmt.Errorf("hashFileContents: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("Seek: %w", err)
+	}
+
+	chunkCtx, cancel := context.WithTimeout(ctx, uploadTimeout(info.Size()))
+	defer cancel()
+
+	wc := s.client.Bucket(s.cfg.Bucket).Object(name).NewWriter(chunkCtx)
+	wc.ChunkSize = resumableChunkSize
+	wc.SendCRC32C = true
+	wc.CRC32C = crc32cSum
+	wc.Metadata = map[string]string{"md5-local": hex.EncodeToString(md5Sum)}
+
+	if _, err := io.Copy(wc, f); err != nil {
+		return fmt.Errorf("io.Copy: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("Writer.Close: %w", err)
+	}
+	fmt.Printf("blob %s uploaded (%d bytes)\n", name, info.Size())
+	return nil
+}
+
+// hashFileContents reads r to EOF, returning its MD5 and CRC32C digests.
+func hashFileContents(r io.Reader) (md5Sum []byte, crc32cSum uint32, err error) {
+	md5Hash := md5.New()
+	crcHash := crc32.New(crc32cTable)
+	if _, err := io.Copy(io.MultiWriter(md5Hash, crcHash), r); err != nil {
+		return nil, 0, err
+	}
+	return md5Hash.Sum(nil), crcHash.Sum32(), nil
+}
@@ -0,0 +1,44 @@
+package logtail
+
+import (
+	"regexp"
+	"strings"
+)
+
+// lineRe matches Vanilla/Paper's standard log line format, e.g.
+// "[15:32:10] [Server thread/INFO]: Foo joined the game".
+var lineRe = regexp.MustCompile(`^\[\d\d:\d\d:\d\d\] \[([^/]+)/([A-Z]+)\]: (.*)$`)
+
+var chatRe = regexp.MustCompile(`^<[^>]+> .+$`)
+
+// parseLine extracts the thread, level and message from a raw server log
+// line, classifying well-known message shapes (player join/leave, chat,
+// world saves) into an Event tag. Lines that don't match the expected
+// format (e.g. a JVM stack trace) are kept as-is with an empty Event.
+func parseLine(raw string) Record {
+	rec := Record{Raw: raw, Message: raw}
+
+	if m := lineRe.FindStringSubmatch(raw); m != nil {
+		rec.Thread = m[1]
+		rec.Level = m[2]
+		rec.Message = m[3]
+		rec.Event = classify(rec.Message)
+	}
+
+	return rec
+}
+
+func classify(message string) string {
+	switch {
+	case strings.Contains(message, "joined the game"):
+		return "player_join"
+	case strings.Contains(message, "left the game"):
+		return "player_leave"
+	case chatRe.MatchString(message):
+		return "player_chat"
+	case strings.Contains(message, "Saved the game"):
+		return "world_save"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,72 @@
+package logtail
+
+import "testing"
+
+func TestParseLineClassifiesWellKnownEvents(t *testing.T) {
+	cases := []struct {
+		name      string
+		raw       string
+		wantEvent string
+		wantMsg   string
+	}{
+		{
+			name:      "player join",
+			raw:       "[15:32:10] [Server thread/INFO]: Steve joined the game",
+			wantEvent: "player_join",
+			wantMsg:   "Steve joined the game",
+		},
+		{
+			name:      "player leave",
+			raw:       "[15:33:00] [Server thread/INFO]: Steve left the game",
+			wantEvent: "player_leave",
+			wantMsg:   "Steve left the game",
+		},
+		{
+			name:      "player chat",
+			raw:       "[15:34:00] [Server thread/INFO]: <Steve> hello world",
+			wantEvent: "player_chat",
+			wantMsg:   "<Steve> hello world",
+		},
+		{
+			name:      "world save",
+			raw:       "[15:35:00] [Server thread/INFO]: Saved the game",
+			wantEvent: "world_save",
+			wantMsg:   "Saved the game",
+		},
+		{
+			name:      "unclassified",
+			raw:       "[15:36:00] [Server thread/INFO]: Starting minecraft server version 1.20.1",
+			wantEvent: "",
+			wantMsg:   "Starting minecraft server version 1.20.1",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := parseLine(tc.raw)
+			if rec.Event != tc.wantEvent {
+				t.Errorf("Event = %q, want %q", rec.Event, tc.wantEvent)
+			}
+			if rec.Message != tc.wantMsg {
+				t.Errorf("Message = %q, want %q", rec.Message, tc.wantMsg)
+			}
+			if rec.Thread != "Server thread" {
+				t.Errorf("Thread = %q, want %q", rec.Thread, "Server thread")
+			}
+			if rec.Level != "INFO" {
+				t.Errorf("Level = %q, want %q", rec.Level, "INFO")
+			}
+		})
+	}
+}
+
+func TestParseLineKeepsUnmatchedLinesAsIs(t *testing.T) {
+	raw := "\tat net.minecraft.server.Main.main(Main.java:123)"
+	rec := parseLine(raw)
+	if rec.Message != raw || rec.Raw != raw {
+		t.Errorf("expected unmatched line to pass through unchanged, got Message=%q Raw=%q", rec.Message, rec.Raw)
+	}
+	if rec.Thread != "" || rec.Level != "" || rec.Event != "" {
+		t.Errorf("expected no thread/level/event for unmatched line, got %+v", rec)
+	}
+}
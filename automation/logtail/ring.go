@@ -0,0 +1,56 @@
+package logtail
+
+import "sync"
+
+// Record is a single parsed line of server log output.
+type Record struct {
+	Raw     string `json:"raw"`
+	Thread  string `json:"thread,omitempty"`
+	Level   string `json:"level,omitempty"`
+	Message string `json:"message"`
+	Event   string `json:"event,omitempty"` // "player_join", "player_leave", "player_chat", "world_save", or ""
+}
+
+// Ring is a fixed-capacity, thread-safe circular buffer of the most recent
+// log records, used to serve tail-style inspection over the admin API
+// without retaining the server's entire log history in memory.
+type Ring struct {
+	mu      sync.Mutex
+	records []Record
+	next    int
+	full    bool
+}
+
+// NewRing builds a Ring that retains the last capacity records.
+func NewRing(capacity int) *Ring {
+	return &Ring{records: make([]Record, capacity)}
+}
+
+// Add appends rec, overwriting the oldest record once the ring is full.
+func (r *Ring) Add(rec Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records[r.next] = rec
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Snapshot returns the buffered records in chronological order.
+func (r *Ring) Snapshot() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		out := make([]Record, r.next)
+		copy(out, r.records[:r.next])
+		return out
+	}
+
+	out := make([]Record, len(r.records))
+	copy(out, r.records[r.next:])
+	copy(out[len(r.records)-r.next:], r.records[:r.next])
+	return out
+}
@@ -0,0 +1,55 @@
+package logtail
+
+import "testing"
+
+func TestRingSnapshotBeforeWraparound(t *testing.T) {
+	r := NewRing(3)
+	r.Add(Record{Message: "a"})
+	r.Add(Record{Message: "b"})
+
+	got := r.Snapshot()
+	want := []string{"a", "b"}
+	if len(got) != len(want) {
+		t.Fatalf("Snapshot() returned %d records, want %d", len(got), len(want))
+	}
+	for i, rec := range got {
+		if rec.Message != want[i] {
+			t.Errorf("Snapshot()[%d].Message = %q, want %q", i, rec.Message, want[i])
+		}
+	}
+}
+
+func TestRingSnapshotAfterWraparound(t *testing.T) {
+	r := NewRing(3)
+	for _, msg := range []string{"a", "b", "c", "d", "e"} {
+		r.Add(Record{Message: msg})
+	}
+
+	got := r.Snapshot()
+	want := []string{"c", "d", "e"}
+	if len(got) != len(want) {
+		t.Fatalf("Snapshot() returned %d records, want %d", len(got), len(want))
+	}
+	for i, rec := range got {
+		if rec.Message != want[i] {
+			t.Errorf("Snapshot()[%d].Message = %q, want %q", i, rec.Message, want[i])
+		}
+	}
+}
+
+func TestRingSnapshotExactlyFull(t *testing.T) {
+	r := NewRing(2)
+	r.Add(Record{Message: "a"})
+	r.Add(Record{Message: "b"})
+
+	got := r.Snapshot()
+	want := []string{"a", "b"}
+	if len(got) != len(want) {
+		t.Fatalf("Snapshot() returned %d records, want %d", len(got), len(want))
+	}
+	for i, rec := range got {
+		if rec.Message != want[i] {
+			t.Errorf("Snapshot()[%d].Message = %q, want %q", i, rec.Message, want[i])
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package logtail
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tailer reads a server process's combined log output line by line,
+// re-emitting each line as a structured slog record (tagging player-join,
+// player-chat and world-save events) and buffering the last N lines for
+// the admin API. Run must always be draining its source — the underlying
+// OS pipe to the server process has a bounded buffer, so anything that
+// stalls the read loop eventually blocks the server itself. Callers that
+// need to know when a particular line shows up (e.g. the backup subsystem
+// waiting for "Saved the game") register via WaitForLine instead of
+// reading the stream themselves.
+type Tailer struct {
+	ring   *Ring
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	waiters []*lineWaiter
+}
+
+type lineWaiter struct {
+	substr string
+	done   chan struct{}
+}
+
+// NewTailer builds a Tailer whose ring buffer retains the last capacity
+// lines.
+func NewTailer(capacity int) *Tailer {
+	return &Tailer{ring: NewRing(capacity), logger: slog.Default()}
+}
+
+// Ring returns the buffer of recent log records, read by the admin API.
+func (t *Tailer) Ring() *Ring {
+	return t.ring
+}
+
+// Run reads lines from src until it's exhausted, writing each raw line to
+// echo (typically os.Stdout), recording a structured entry in the ring
+// buffer and via slog, and waking any WaitForLine callers whose substring
+// just appeared. It should be the only reader of src.
+func (t *Tailer) Run(src io.Reader, echo io.Writer) {
+	scanner := bufio.NewScanner(src)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fmt.Fprintln(echo, line)
+
+		rec := parseLine(line)
+		t.ring.Add(rec)
+		t.log(rec)
+		t.wake(line)
+	}
+}
+
+// WaitForLine blocks until a line containing substr is read by Run, or
+// returns an error once timeout elapses.
+func (t *Tailer) WaitForLine(substr string, timeout time.Duration) error {
+	w := &lineWaiter{substr: substr, done: make(chan struct{})}
+
+	t.mu.Lock()
+	t.waiters = append(t.waiters, w)
+	t.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-time.After(timeout):
+		t.removeWaiter(w)
+		return fmt.Errorf("timed out waiting for %q", substr)
+	}
+}
+
+func (t *Tailer) wake(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	remaining := t.waiters[:0]
+	for _, w := range t.waiters {
+		if strings.Contains(line, w.substr) {
+			close(w.done)
+		} else {
+			remaining = append(remaining, w)
+		}
+	}
+	t.waiters = remaining
+}
+
+func (t *Tailer) removeWaiter(target *lineWaiter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, w := range t.waiters {
+		if w == target {
+			t.waiters = append(t.waiters[:i], t.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+func (t *Tailer) log(rec Record) {
+	attrs := []any{slog.String("level", rec.Level), slog.String("thread", rec.Thread)}
+	if rec.Event != "" {
+		attrs = append(attrs, slog.String("event", rec.Event))
+	}
+	t.logger.Info(rec.Message, attrs...)
+}
@@ -3,22 +3,24 @@ package main
 import (
 	"context"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
-	"path"
-	"strings"
+	"strconv"
 	"syscall"
 	"time"
 
-	"cloud.google.com/go/storage"
 	"github.com/joho/godotenv"
+	"github.com/kCanoe/mc-server-wrapper/automation/admin"
+	"github.com/kCanoe/mc-server-wrapper/automation/backup"
+	"github.com/kCanoe/mc-server-wrapper/automation/logtail"
+	"github.com/kCanoe/mc-server-wrapper/automation/rcon"
+	"github.com/kCanoe/mc-server-wrapper/automation/runtime"
 )
 
-func validateStartup() error {
+func validateStartup(runtimeKind string) error {
 	// check that network connection is available
 	client := http.Client{Timeout: 2 * time.Second}
 	resp, err := client.Head("https://www.google.com")
@@ -34,6 +36,13 @@ func validateStartup() error {
 		return fmt.Errorf("failed to load github access token")
 	}
 	fmt.Println("located github access token")
+
+	// the docker runtime brings its own JDK in the image, so there's
+	// nothing to validate on the host
+	if runtimeKind == "docker" {
+		return nil
+	}
+
 	// check JDK version and installation
 	cmd := exec.Command("java", "-version")
 	out, err := cmd.CombinedOutput()
@@ -45,98 +54,120 @@ func validateStartup() error {
 	return nil
 }
 
-func startServer() (*exec.Cmd, io.WriteCloser, error) {
-	cmd := exec.Command("java", "-Xmx4G", "-jar", "server.jar", "nogui")
-	serverJarPath := os.Getenv("SERVER_JAR_PATH")
-	cmd.Stdout, cmd.Stderr, cmd.Dir = os.Stdout, os.Stderr, serverJarPath
-
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return cmd, nil, fmt.Errorf("failed to acquire pipe for server process: %w", err)
-	}
-
-	if err := cmd.Start(); err != nil {
-		return cmd, nil, fmt.Errorf("failed to start server instance: %w", err)
+// backupConfig builds a backup.Config from the environment, applying
+// defaults for anything not set in the env file.
+func backupConfig() backup.Config {
+	interval := 30 * time.Minute
+	if raw := os.Getenv("BACKUP_INTERVAL_MINUTES"); raw != "" {
+		if minutes, err := strconv.Atoi(raw); err == nil && minutes > 0 {
+			interval = time.Duration(minutes) * time.Minute
+		}
+	}
+	keepFull := 7
+	if raw := os.Getenv("BACKUP_KEEP_FULL"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			keepFull = n
+		}
+	}
+	keepIncr := 24
+	if raw := os.Getenv("BACKUP_KEEP_INCREMENTAL"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			keepIncr = n
+		}
+	}
+
+	serverDir := os.Getenv("SERVER_JAR_PATH")
+	return backup.Config{
+		Bucket:       "world-archives",
+		WorldDir:     serverDir + "/" + os.Getenv("WORLD_NAME"),
+		ServerDir:    serverDir,
+		ManifestPath: serverDir + "/.backup-manifest.json",
+		Interval:     interval,
+		KeepFull:     keepFull,
+		KeepIncr:     keepIncr,
 	}
-
-	return cmd, stdin, nil
 }
 
-func shutdownServer(cmd *exec.Cmd, stdin io.WriteCloser) error {
-	fmt.Println("sending stop command to server process")
-
-	if _, err := io.WriteString(stdin, "stop\n"); err != nil {
-		return fmt.Errorf("error writing to server process stdin: %w", err)
+// restoreIfMissing pulls the latest full backup down from the bucket
+// before the server starts, so a fresh VM with an empty world directory
+// comes up with the most recent world instead of generating a new one.
+// It's a no-op if the world directory already exists.
+func restoreIfMissing(svc *backup.Service, cfg backup.Config) error {
+	if _, err := os.Stat(cfg.WorldDir); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("stat world dir: %w", err)
 	}
 
-	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf("server process failed to close: %w", err)
-	}
-	return nil
+	fmt.Println("world directory missing, restoring latest backup")
+	return svc.RestoreLatest(context.Background(), cfg.ServerDir)
 }
 
-func uploadFile(bucket, object, file string) error {
-	ctx := context.Background()
-	client, err := storage.NewClient(ctx)
-	if err != nil {
-		return fmt.Errorf("storage.NewClient: %w", err)
-	}
-	defer client.Close()
-
-	f, err := os.Open(file)
-	if err != nil {
-		return fmt.Errorf("os.Open: %w", err)
-	}
-	defer f.Close()
-
-	ctx, cancel := context.WithTimeout(ctx, time.Second*60)
-	defer cancel()
-
-	o := client.Bucket(bucket).Object(object)
-	o = o.If(storage.Conditions{DoesNotExist: true})
-
-	wc := o.NewWriter(ctx)
-	if _, err = io.Copy(wc, f); err != nil {
-		return fmt.Errorf("io.Copy: %w", err)
-	}
-	if err := wc.Close(); err != nil {
-		return fmt.Errorf("Writer.Close: %w", err)
-	}
-	fmt.Printf("blob %v uploaded.\n", object)
-	return nil
+// connectRcon dials the server's RCON listener if RCON_PORT/RCON_PASSWORD
+// are configured, retrying briefly since the listener isn't open the
+// instant the process starts. It returns a nil client (not an error) when
+// RCON isn't configured, so callers fall back to stdin coordination.
+func connectRcon() (*rcon.Client, error) {
+	port := os.Getenv("RCON_PORT")
+	password := os.Getenv("RCON_PASSWORD")
+	if port == "" || password == "" {
+		return nil, nil
+	}
+
+	addr := "127.0.0.1:" + port
+	var lastErr error
+	for i := 0; i < 10; i++ {
+		client, err := rcon.Dial(addr, password)
+		if err == nil {
+			return client, nil
+		}
+		lastErr = err
+		time.Sleep(2 * time.Second)
+	}
+	return nil, fmt.Errorf("failed to connect to rcon at %s: %w", addr, lastErr)
 }
 
-func backupWorld() error {
-	// compress the world files
-	// create name - mc-world-[date]-[time].tar.gz
-	timeString := time.Now().Format("2006-01-02T15:04:05")
-	cleanTimeString := strings.ReplaceAll(timeString, ":", "-")
-	nameString := "mc-world-backup" + "-" + cleanTimeString + ".tar.xz"
-
-	fmt.Println("compressing world files")
-	worldDir := os.Getenv("WORLD_NAME")
-	compressCmd := exec.Command("tar", "-cJvf", nameString, worldDir)
-	compressCmd.Dir = os.Getenv("SERVER_JAR_PATH")
-	if err := compressCmd.Run(); err != nil {
-		return fmt.Errorf("failed to compress world files: %w", err)
-	}
-
-	//(todo) upload the world files to gcs
-	fmt.Println("uploading world files to storage bucket")
-	filePath := path.Join(os.Getenv("SERVER_JAR_PATH"), nameString)
-	uploadFile("world-archives", nameString, filePath)
-
-	// clean up world files tar ball
-	fmt.Println("cleaning up local archive file")
-	deleteCmd := exec.Command("rm", nameString)
-	deleteCmd.Dir = os.Getenv("SERVER_JAR_PATH")
-	if err := deleteCmd.Run(); err != nil {
-		return fmt.Errorf("failed to delete local world archive: %w", err)
+// runScheduledBackups issues a backup via svc on every tick of cfg.Interval
+// until stop is closed, logging (but not dying on) individual failures so a
+// single bad snapshot doesn't take down the wrapper.
+func runScheduledBackups(svc *backup.Service, cfg backup.Config, coord backup.SaveCoordinator, stop <-chan struct{}) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			fmt.Println("running scheduled world backup")
+			if err := svc.Run(context.Background(), coord); err != nil {
+				log.Printf("scheduled backup failed: %v", err)
+			}
+		case <-stop:
+			return
+		}
 	}
+}
 
-	return nil
+// startAdminAPI binds the admin HTTP API to localhost only; it's never
+// meant to be reachable off the box.
+func startAdminAPI(rconClient *rcon.Client, backupSvc *backup.Service, coord backup.SaveCoordinator, logs *logtail.Ring, sigs chan<- os.Signal) {
+	token := os.Getenv("ADMIN_API_TOKEN")
+	if token == "" {
+		fmt.Println("ADMIN_API_TOKEN not set, admin API disabled")
+		return
+	}
+	port := os.Getenv("ADMIN_API_PORT")
+	if port == "" {
+		port = "8090"
+	}
+
+	srv := admin.New(token, rconClient, backupSvc, coord, logs, sigs)
+	addr := "127.0.0.1:" + port
+	fmt.Printf("admin API listening on %s\n", addr)
+	go func() {
+		if err := http.ListenAndServe(addr, srv.Handler()); err != nil {
+			log.Printf("admin API stopped: %v", err)
+		}
+	}()
 }
 
 func main() {
@@ -144,26 +175,62 @@ func main() {
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
 
+	runtimeKind := os.Getenv("RUNTIME")
+
 	// run server startup routine
-	if err := validateStartup(); err != nil {
+	if err := validateStartup(runtimeKind); err != nil {
 		log.Fatal(fmt.Errorf("failed to validate startup: %w", err))
 	}
 
-	serverProcess, serverStdin, err := startServer()
+	cfg := backupConfig()
+	backupSvc, err := backup.NewService(context.Background(), cfg)
+	if err != nil {
+		log.Fatal("failed to set up backup subsystem: %w", err)
+	}
+	defer backupSvc.Close()
+
+	if err := restoreIfMissing(backupSvc, cfg); err != nil {
+		log.Fatal("failed to restore world from backup: %w", err)
+	}
+
+	serverRuntime := runtime.New(runtimeKind)
+	serverStdin, serverTailer, err := serverRuntime.Start()
 	if err != nil {
 		log.Fatal("failed to start server: %w", err)
 	}
 
+	rconClient, err := connectRcon()
+	if err != nil {
+		log.Printf("rcon unavailable, falling back to stdin coordination: %v", err)
+	}
+
+	var coord backup.SaveCoordinator
+	if rconClient != nil {
+		coord = backup.NewRconCoordinator(rconClient)
+	} else {
+		coord = backup.NewStdinCoordinator(serverStdin, serverTailer)
+	}
+
+	startAdminAPI(rconClient, backupSvc, coord, serverTailer.Ring(), sigs)
+
+	stopBackups := make(chan struct{})
+	go runScheduledBackups(backupSvc, cfg, coord, stopBackups)
+
 	// wait for VM shutdown signal
 	sig := <-sigs
 	fmt.Printf("recieved signal: %s, starting graceful shutdown\n", sig)
+	close(stopBackups)
+
+	if err := backupSvc.Run(context.Background(), coord); err != nil {
+		log.Fatal("error in backing up world files: %w", err)
+	}
 
 	// run server shutdown routine
-	if err := shutdownServer(serverProcess, serverStdin); err != nil {
+	if err := serverRuntime.Stop(serverStdin); err != nil {
 		log.Fatal("error shutting down server: %w", err)
 	}
-	if err := backupWorld(); err != nil {
-		log.Fatal("error in backing up world files: %w", err)
+	if rconClient != nil {
+		rconClient.Close()
 	}
 
 	fmt.Println("successful shutdown complete")
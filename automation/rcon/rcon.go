@@ -0,0 +1,147 @@
+// Package rcon implements a minimal client for the Source RCON protocol
+// used by vanilla and Paper Minecraft servers, letting automation issue
+// console commands over the network instead of the server process's stdin
+// pipe.
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Packet types, per the Source RCON spec.
+const (
+	typeResponse    int32 = 0
+	typeExecCommand int32 = 2
+	typeAuth        int32 = 3
+)
+
+const maxPacketSize = 4096
+
+// Client is a connection to a running server's RCON listener, authenticated
+// once at Dial time. It's safe for concurrent use: the admin HTTP API and
+// the scheduled-backup goroutine can both issue commands against the same
+// connection, and mu serializes each request/response round trip so their
+// writes and reads can't interleave.
+type Client struct {
+	conn   net.Conn
+	nextID int32
+
+	mu sync.Mutex
+}
+
+// Dial connects to addr (host:port) and authenticates with password. It
+// returns an error if the connection or authentication fails.
+func Dial(addr, password string) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("net.DialTimeout: %w", err)
+	}
+
+	c := &Client{conn: conn, nextID: 1}
+	id, err := c.send(typeAuth, password)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("rcon auth: %w", err)
+	}
+	if id == -1 {
+		conn.Close()
+		return nil, fmt.Errorf("rcon auth: bad password")
+	}
+	return c, nil
+}
+
+// Close closes the underlying connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Execute sends command to the server and returns its response body, e.g.
+// Execute("list") or Execute("save-all flush").
+func (c *Client) Execute(command string) (string, error) {
+	body, err := c.request(typeExecCommand, command)
+	if err != nil {
+		return "", fmt.Errorf("rcon execute %q: %w", command, err)
+	}
+	return body, nil
+}
+
+// send issues a packet and returns just the response id, used for auth
+// where the response body is empty.
+func (c *Client) send(packetType int32, payload string) (int32, error) {
+	_, id, err := c.roundTrip(packetType, payload)
+	return id, err
+}
+
+// request issues a packet and returns the response body.
+func (c *Client) request(packetType int32, payload string) (string, error) {
+	body, _, err := c.roundTrip(packetType, payload)
+	return body, err
+}
+
+func (c *Client) roundTrip(packetType int32, payload string) (string, int32, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := c.nextID
+	c.nextID++
+
+	if err := writePacket(c.conn, id, packetType, payload); err != nil {
+		return "", 0, fmt.Errorf("writePacket: %w", err)
+	}
+
+	respID, _, body, err := readPacket(c.conn)
+	if err != nil {
+		return "", 0, fmt.Errorf("readPacket: %w", err)
+	}
+	if packetType == typeAuth && respID != id {
+		return "", -1, nil
+	}
+	return body, respID, nil
+}
+
+// writePacket encodes and writes a single RCON packet: a 4-byte
+// little-endian length prefix (covering everything that follows),
+// followed by the request id, the packet type, the null-terminated
+// payload, and a trailing empty null-terminated string.
+func writePacket(w io.Writer, id, packetType int32, payload string) error {
+	var body bytes.Buffer
+	binary.Write(&body, binary.LittleEndian, id)
+	binary.Write(&body, binary.LittleEndian, packetType)
+	body.WriteString(payload)
+	body.WriteByte(0)
+	body.WriteByte(0)
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, int32(body.Len()))
+	buf.Write(body.Bytes())
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// readPacket reads and decodes a single RCON response packet.
+func readPacket(r io.Reader) (id, packetType int32, payload string, err error) {
+	var length int32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return 0, 0, "", fmt.Errorf("reading length: %w", err)
+	}
+	if length < 10 || length > maxPacketSize {
+		return 0, 0, "", fmt.Errorf("invalid packet length %d", length)
+	}
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, 0, "", fmt.Errorf("reading body: %w", err)
+	}
+
+	id = int32(binary.LittleEndian.Uint32(buf[0:4]))
+	packetType = int32(binary.LittleEndian.Uint32(buf[4:8]))
+	payload = string(bytes.TrimRight(buf[8:length-2], "\x00"))
+	return id, packetType, payload, nil
+}
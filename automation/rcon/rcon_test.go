@@ -0,0 +1,61 @@
+package rcon
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteReadPacketRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writePacket(&buf, 7, typeExecCommand, "save-all flush"); err != nil {
+		t.Fatalf("writePacket: %v", err)
+	}
+
+	id, packetType, payload, err := readPacket(&buf)
+	if err != nil {
+		t.Fatalf("readPacket: %v", err)
+	}
+	if id != 7 {
+		t.Errorf("id = %d, want 7", id)
+	}
+	if packetType != typeExecCommand {
+		t.Errorf("packetType = %d, want %d", packetType, typeExecCommand)
+	}
+	if payload != "save-all flush" {
+		t.Errorf("payload = %q, want %q", payload, "save-all flush")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("readPacket left %d trailing bytes", buf.Len())
+	}
+}
+
+func TestWritePacketLengthPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writePacket(&buf, 1, typeAuth, "hunter2"); err != nil {
+		t.Fatalf("writePacket: %v", err)
+	}
+
+	// length prefix + its own 4 bytes should account for the whole buffer
+	var length int32
+	data := buf.Bytes()
+	length = int32(data[0]) | int32(data[1])<<8 | int32(data[2])<<16 | int32(data[3])<<24
+	if int(length)+4 != len(data) {
+		t.Errorf("length prefix %d doesn't match buffer size %d", length, len(data))
+	}
+}
+
+func TestReadPacketRejectsOversizedLength(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xff, 0xff, 0x7f}) // huge length, little-endian
+	if _, _, _, err := readPacket(&buf); err == nil {
+		t.Fatal("expected error for oversized packet length, got nil")
+	}
+}
+
+func TestReadPacketRejectsUndersizedLength(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write([]byte{1, 0, 0, 0}) // length 1, below the 10-byte minimum
+	if _, _, _, err := readPacket(&buf); err == nil {
+		t.Fatal("expected error for undersized packet length, got nil")
+	}
+}
@@ -0,0 +1,171 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/kCanoe/mc-server-wrapper/automation/logtail"
+)
+
+// containerName is fixed so a restart of the wrapper can find and clean up
+// whatever a prior run left behind, rather than Docker auto-generating a new
+// name every boot.
+const containerName = "mc-server"
+
+// DockerRuntime runs the Minecraft server inside a container, so the JDK
+// comes from the image named by SERVER_IMAGE regardless of what's installed
+// on the host VM. The image is pulled on every Start, and the default
+// itzg/minecraft-server:latest tag is not pinned, so the JDK stays the same
+// across deploys only when SERVER_IMAGE names a fixed tag or digest.
+type DockerRuntime struct {
+	cli         *client.Client
+	containerID string
+}
+
+// NewDockerRuntime builds a DockerRuntime talking to the Docker daemon via
+// the environment (DOCKER_HOST, etc.), matching the docker CLI's own
+// resolution rules.
+func NewDockerRuntime() *DockerRuntime {
+	return &DockerRuntime{}
+}
+
+func (r *DockerRuntime) Start() (io.WriteCloser, *logtail.Tailer, error) {
+	ctx := context.Background()
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, nil, fmt.Errorf("docker.NewClientWithOpts: %w", err)
+	}
+	r.cli = cli
+
+	image := envOr("SERVER_IMAGE", "itzg/minecraft-server:latest")
+	serverJarPath := os.Getenv("SERVER_JAR_PATH")
+	worldDir := os.Getenv("WORLD_NAME")
+
+	fmt.Printf("pulling server image %s\n", image)
+	pull, err := cli.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("cli.ImagePull: %w", err)
+	}
+	// ImagePull returns success as soon as the pull starts; failures like a
+	// bad tag or missing image only show up as error records inside this
+	// JSON stream, so it has to actually be parsed, not just drained.
+	pullErr := jsonmessage.DisplayJSONMessagesStream(pull, os.Stdout, 0, false, nil)
+	pull.Close()
+	if pullErr != nil {
+		return nil, nil, fmt.Errorf("pulling image %s: %w", image, pullErr)
+	}
+
+	// a prior ungraceful exit (crash, OOM-kill, VM power loss) can leave the
+	// previous container behind under this same name, which would otherwise
+	// fail ContainerCreate below with "name already in use"
+	if err := cli.ContainerRemove(ctx, containerName, types.ContainerRemoveOptions{Force: true}); err != nil && !client.IsErrNotFound(err) {
+		return nil, nil, fmt.Errorf("cli.ContainerRemove: %w", err)
+	}
+
+	resources := container.Resources{
+		Memory:   4 * 1024 * 1024 * 1024, // equivalent of -Xmx4G
+		NanoCPUs: 2_000_000_000,          // 2 CPUs
+	}
+
+	// itzg/minecraft-server refuses to boot without an explicit EULA
+	// acceptance; TYPE/VERSION/MEMORY are set too so the container actually
+	// matches the runtime limits and version this wrapper thinks it's
+	// running, instead of silently falling back to the image's defaults.
+	env := []string{
+		"EULA=TRUE",
+		"TYPE=" + envOr("SERVER_TYPE", "VANILLA"),
+		"VERSION=" + envOr("SERVER_VERSION", "LATEST"),
+		"MEMORY=" + envOr("SERVER_MEMORY", "4G"),
+	}
+
+	resp, err := cli.ContainerCreate(ctx,
+		&container.Config{
+			Image:        image,
+			Env:          env,
+			Tty:          true,
+			OpenStdin:    true,
+			AttachStdin:  true,
+			AttachStdout: true,
+			AttachStderr: true,
+			WorkingDir:   "/server",
+		},
+		&container.HostConfig{
+			Binds: []string{
+				serverJarPath + ":/server",
+				serverJarPath + "/" + worldDir + ":/server/" + worldDir,
+			},
+			Resources: resources,
+		},
+		nil, nil, containerName,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cli.ContainerCreate: %w", err)
+	}
+	r.containerID = resp.ID
+
+	attach, err := cli.ContainerAttach(ctx, r.containerID, types.ContainerAttachOptions{
+		Stream: true, Stdin: true, Stdout: true, Stderr: true,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("cli.ContainerAttach: %w", err)
+	}
+
+	if err := cli.ContainerStart(ctx, r.containerID, types.ContainerStartOptions{}); err != nil {
+		return nil, nil, fmt.Errorf("cli.ContainerStart: %w", err)
+	}
+
+	tailer := logtail.NewTailer(logRingCapacity)
+	go tailer.Run(attach.Reader, os.Stdout)
+
+	return attach.Conn, tailer, nil
+}
+
+func (r *DockerRuntime) Stop(stdin io.WriteCloser) error {
+	fmt.Println("sending stop command to server container")
+
+	if _, err := io.WriteString(stdin, "stop\n"); err != nil {
+		return fmt.Errorf("error writing to server container stdin: %w", err)
+	}
+
+	ctx := context.Background()
+	statusCh, errCh := r.cli.ContainerWait(ctx, r.containerID, container.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		if err != nil {
+			fmt.Printf("error waiting for container exit, falling back to ContainerStop: %v\n", err)
+			if stopErr := r.forceStop(ctx); stopErr != nil {
+				return stopErr
+			}
+		}
+	case <-statusCh:
+	case <-time.After(30 * time.Second):
+		if err := r.forceStop(ctx); err != nil {
+			return err
+		}
+	}
+
+	return r.cli.Close()
+}
+
+func (r *DockerRuntime) forceStop(ctx context.Context) error {
+	timeout := 10
+	if err := r.cli.ContainerStop(ctx, r.containerID, container.StopOptions{Timeout: &timeout}); err != nil {
+		return fmt.Errorf("cli.ContainerStop: %w", err)
+	}
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,63 @@
+package runtime
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/kCanoe/mc-server-wrapper/automation/logtail"
+)
+
+// ExecRuntime runs the Minecraft server as a bare `java -jar` subprocess on
+// the host, the original way this wrapper ran the server.
+type ExecRuntime struct {
+	cmd *exec.Cmd
+}
+
+// NewExecRuntime builds an ExecRuntime. SERVER_JAR_PATH is read at Start
+// time, matching the rest of the wrapper's env-driven configuration.
+func NewExecRuntime() *ExecRuntime {
+	return &ExecRuntime{}
+}
+
+func (r *ExecRuntime) Start() (io.WriteCloser, *logtail.Tailer, error) {
+	cmd := exec.Command("java", "-Xmx4G", "-jar", "server.jar", "nogui")
+	cmd.Dir = os.Getenv("SERVER_JAR_PATH")
+	cmd.Stderr = os.Stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to acquire stdin pipe for server process: %w", err)
+	}
+
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to acquire stdout pipe for server process: %w", err)
+	}
+
+	tailer := logtail.NewTailer(logRingCapacity)
+	go tailer.Run(stdoutPipe, os.Stdout)
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("failed to start server instance: %w", err)
+	}
+
+	r.cmd = cmd
+	return stdin, tailer, nil
+}
+
+func (r *ExecRuntime) Stop(stdin io.WriteCloser) error {
+	fmt.Println("sending stop command to server process")
+
+	if _, err := io.WriteString(stdin, "stop\n"); err != nil {
+		return fmt.Errorf("error writing to server process stdin: %w", err)
+	}
+
+	if err := r.cmd.Wait(); err != nil {
+		return fmt.Errorf("server process failed to close: %w", err)
+	}
+	return nil
+}
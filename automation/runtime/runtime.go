@@ -0,0 +1,39 @@
+// Package runtime abstracts over the ways the Minecraft server process can
+// be launched, so that main can start/stop it without caring whether it's a
+// bare java process or a container.
+package runtime
+
+import (
+	"io"
+
+	"github.com/kCanoe/mc-server-wrapper/automation/logtail"
+)
+
+const logRingCapacity = 500
+
+// ServerRuntime launches and tears down a running Minecraft server,
+// exposing the same console-command/log-tail surface regardless of how the
+// server is actually hosted.
+type ServerRuntime interface {
+	// Start launches the server and returns a writer for sending console
+	// commands (e.g. "save-all", "stop") and the Tailer continuously
+	// draining its log output, which callers use both to read the
+	// structured log ring buffer and to wait for specific lines (e.g.
+	// "Saved the game"). Output is also echoed to os.Stdout.
+	Start() (io.WriteCloser, *logtail.Tailer, error)
+
+	// Stop sends the in-game stop command over stdin and blocks until the
+	// server has fully exited.
+	Stop(stdin io.WriteCloser) error
+}
+
+// New builds the ServerRuntime selected by the RUNTIME env var ("exec" or
+// "docker"), defaulting to ExecRuntime when unset.
+func New(kind string) ServerRuntime {
+	switch kind {
+	case "docker":
+		return NewDockerRuntime()
+	default:
+		return NewExecRuntime()
+	}
+}